@@ -3,8 +3,11 @@ package deb
 import (
 	"archive/tar"
 	"bufio"
+	"compress/bzip2"
 	"compress/gzip"
-	"fmt"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
 	"io"
 	"os"
 	"strconv"
@@ -19,22 +22,42 @@ import (
 
 type DEBPackage struct {
 	Architecture  string
+	Breaks        [][]Dependency
 	BuiltUsing    []string
+	Conflicts     [][]Dependency
 	DebVersion    string
-	Depends       []string
+	Depends       [][]Dependency
 	Description   string
 	Filename      string
 	Homepage      string
 	InstalledSize int64
+	MD5           []byte
 	Maintainer    string
 	Modified      time.Time
 	Name          string
+	PreDepends    [][]Dependency
 	Priority      string
-	Recommends    []string
+	Provides      [][]Dependency
+	Raw           Stanza
+	Recommends    [][]Dependency
+	Replaces      [][]Dependency
 	Section       string
+	SHA1          []byte
+	SHA256        []byte
+	Size          int64
+	Suggests      [][]Dependency
 	Version       string
 }
 
+// ParseOptions carries the metadata Parse normally derives from the
+// filesystem (Filename, Modified) for callers going through ParseReader or
+// ParseReaderAt, where no such metadata exists.
+type ParseOptions struct {
+	Filename string
+	Modified time.Time
+}
+
+// Parse reads and parses the .deb archive at filename.
 func Parse(filename string) (*DEBPackage, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -45,49 +68,93 @@ func Parse(filename string) (*DEBPackage, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "Cannot stat %s", filename)
 	}
-	deb := &DEBPackage{
+	return ParseReaderAt(file, info.Size(), ParseOptions{
 		Filename: filename,
 		Modified: info.ModTime(),
+	})
+}
+
+// ParseReaderAt parses a .deb archive of the given size from r, such as an
+// os.File, a bytes.Reader, or a zip.File opened for random access.
+func ParseReaderAt(r io.ReaderAt, size int64, opts ...ParseOptions) (*DEBPackage, error) {
+	return ParseReader(io.NewSectionReader(r, 0, size), opts...)
+}
+
+// ParseReader parses a .deb archive from a single-pass io.Reader, such as an
+// http.Response.Body or an in-memory buffer.
+func ParseReader(r io.Reader, opts ...ParseOptions) (*DEBPackage, error) {
+	deb := &DEBPackage{}
+	if len(opts) > 0 {
+		deb.Filename = opts[0].Filename
+		deb.Modified = opts[0].Modified
+	}
+	md5_hash := md5.New()
+	sha1_hash := sha1.New()
+	sha256_hash := sha256.New()
+	counter := &byte_counter{}
+	tee_reader := io.TeeReader(r, io.MultiWriter(md5_hash, sha1_hash, sha256_hash, counter))
+	if err := parse_ar(deb, tee_reader); err != nil {
+		return nil, err
 	}
-	ar_reader := ar.NewReader(file)
+	deb.Size = counter.n
+	deb.MD5 = md5_hash.Sum(nil)
+	deb.SHA1 = sha1_hash.Sum(nil)
+	deb.SHA256 = sha256_hash.Sum(nil)
+	return deb, nil
+}
+
+// byte_counter is an io.Writer that only counts the bytes written to it, so
+// it can ride along in an io.MultiWriter to measure a streamed archive.
+type byte_counter struct {
+	n int64
+}
+
+func (c *byte_counter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+func parse_ar(deb *DEBPackage, reader io.Reader) error {
+	ar_reader := ar.NewReader(reader)
 	for {
 		header, err := ar_reader.Next()
 		if err != nil {
 			if err == io.EOF {
-				return deb, nil
+				return nil
 			} else {
-				return nil, errors.Wrapf(err, "Error reading %s", filename)
+				return errors.Wrap(err, "Error reading ar archive")
 			}
 		}
 		if header.Name == "debian-binary" {
 			limit_reader := io.LimitReader(ar_reader, header.Size)
 			if err := parse_debian_binary(deb, limit_reader); err != nil {
-				return nil, err
+				return err
 			}
 		} else if header.Name == "control.tar.gz" {
 			limit_reader := io.LimitReader(ar_reader, header.Size)
 			if err := parse_control_tar_gz(deb, limit_reader); err != nil {
-				return nil, err
+				return err
 			}
 		} else if header.Name == "control.tar.xz" {
 			limit_reader := io.LimitReader(ar_reader, header.Size)
 			if err := parse_control_tar_xz(deb, limit_reader); err != nil {
-				return nil, err
+				return err
 			}
 		} else if header.Name == "control.tar.zst" {
 			limit_reader := io.LimitReader(ar_reader, header.Size)
 			if err := parse_control_tar_zst(deb, limit_reader); err != nil {
-				return nil, err
+				return err
 			}
-		} else if header.Name == "data.tar.gz" {
-			return deb, nil
-		} else if header.Name == "data.tar.xz" {
-			return deb, nil
-		} else if header.Name == "data.tar.zst" {
-			return deb, nil
-		} else {
-			fmt.Println(header.Name)
+		} else if header.Name == "control.tar.bz2" {
+			limit_reader := io.LimitReader(ar_reader, header.Size)
+			if err := parse_control_tar_bz2(deb, limit_reader); err != nil {
+				return err
+			}
+		} else if header.Name == "data.tar.gz" || header.Name == "data.tar.xz" ||
+			header.Name == "data.tar.zst" || header.Name == "data.tar.bz2" {
+			// Contents are not extracted here.
 		}
+		// Unrecognized ar members are ignored.
 	}
 }
 
@@ -126,6 +193,11 @@ func parse_control_tar_zst(deb *DEBPackage, reader io.Reader) error {
 	return parse_control_tar(deb, zst_reader)
 }
 
+func parse_control_tar_bz2(deb *DEBPackage, reader io.Reader) error {
+	bz2_reader := bzip2.NewReader(reader)
+	return parse_control_tar(deb, bz2_reader)
+}
+
 func parse_control_tar(deb *DEBPackage, reader io.Reader) error {
 	tar_reader := tar.NewReader(reader)
 	for {
@@ -139,67 +211,92 @@ func parse_control_tar(deb *DEBPackage, reader io.Reader) error {
 		}
 		if header.Name == "./control" {
 			limit_reader := io.LimitReader(tar_reader, header.Size)
-			parse_control(deb, limit_reader)
+			if err := parse_control(deb, limit_reader); err != nil {
+				return err
+			}
 		}
 	}
 }
 
 func parse_control(deb *DEBPackage, reader io.Reader) error {
-	scanner := bufio.NewScanner(reader)
-	in_description := false
-	for scanner.Scan() {
-		line := scanner.Text()
-		if in_description {
-			if !strings.ContainsAny(line[0:1], " \t\r\n") {
-				in_description = false
-			} else {
-				deb.Description += line
-			}
+	stanza, err := ParseStanza(reader)
+	if err != nil {
+		return errors.Wrap(err, "Error parsing control file")
+	}
+	deb.Raw = stanza
+	if value, ok := stanza.Get("Architecture"); ok {
+		deb.Architecture = value
+	}
+	if value, ok := stanza.Get("Built-Using"); ok {
+		deb.BuiltUsing = strings.SplitN(value, ", ", -1)
+	}
+	if value, ok := stanza.Get("Depends"); ok {
+		if deb.Depends, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Depends")
 		}
-		if !in_description {
-			name, value := parse_control_field(deb, line)
-			switch name {
-			case "Architecture":
-				deb.Architecture = value
-			case "Built-Using":
-				deb.BuiltUsing = strings.SplitN(value, ", ", -1)
-			case "Depends":
-				deb.Depends = strings.SplitN(value, ", ", -1)
-			case "Description":
-				deb.Description = value
-				in_description = true
-			case "Homepage":
-				deb.Homepage = value
-			case "Installed-Size":
-				if size, err := strconv.ParseInt(value, 10, 64); err != nil {
-					return errors.Wrap(err, "Error parsing Installed-Size")
-				} else {
-					deb.InstalledSize = size
-				}
-			case "Maintainer":
-				deb.Maintainer = value
-			case "Package":
-				deb.Name = value
-			case "Priority":
-				deb.Priority = value
-			case "Recommends":
-				deb.Recommends = strings.SplitN(value, ", ", -1)
-			case "Section":
-				deb.Section = value
-			case "Version":
-				deb.Version = value
-			}
+	}
+	if value, ok := stanza.Get("Pre-Depends"); ok {
+		if deb.PreDepends, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Pre-Depends")
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return errors.Wrap(err, "Error parsing control file")
+	if value, ok := stanza.Get("Conflicts"); ok {
+		if deb.Conflicts, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Conflicts")
+		}
 	}
-	return nil
-}
-
-func parse_control_field(deb *DEBPackage, line string) (string, string) {
-	if fields := strings.SplitN(line, ": ", 2); len(fields) == 2 {
-		return strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+	if value, ok := stanza.Get("Breaks"); ok {
+		if deb.Breaks, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Breaks")
+		}
+	}
+	if value, ok := stanza.Get("Provides"); ok {
+		if deb.Provides, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Provides")
+		}
+	}
+	if value, ok := stanza.Get("Replaces"); ok {
+		if deb.Replaces, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Replaces")
+		}
+	}
+	if value, ok := stanza.Get("Suggests"); ok {
+		if deb.Suggests, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Suggests")
+		}
 	}
-	return "", ""
+	if value, ok := stanza.Get("Description"); ok {
+		deb.Description = value
+	}
+	if value, ok := stanza.Get("Homepage"); ok {
+		deb.Homepage = value
+	}
+	if value, ok := stanza.Get("Installed-Size"); ok {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "Error parsing Installed-Size")
+		}
+		deb.InstalledSize = size
+	}
+	if value, ok := stanza.Get("Maintainer"); ok {
+		deb.Maintainer = value
+	}
+	if value, ok := stanza.Get("Package"); ok {
+		deb.Name = value
+	}
+	if value, ok := stanza.Get("Priority"); ok {
+		deb.Priority = value
+	}
+	if value, ok := stanza.Get("Recommends"); ok {
+		if deb.Recommends, err = group_dependencies(value); err != nil {
+			return errors.Wrap(err, "Error parsing Recommends")
+		}
+	}
+	if value, ok := stanza.Get("Section"); ok {
+		deb.Section = value
+	}
+	if value, ok := stanza.Get("Version"); ok {
+		deb.Version = value
+	}
+	return nil
 }
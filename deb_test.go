@@ -0,0 +1,64 @@
+package deb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const fixture_deb = "testdata/fixture.deb"
+
+func TestParseHashesMatchSystemTools(t *testing.T) {
+	deb, err := Parse(fixture_deb)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", fixture_deb, err)
+	}
+
+	info, err := os.Stat(fixture_deb)
+	if err != nil {
+		t.Fatalf("Stat(%s): %v", fixture_deb, err)
+	}
+	if deb.Size != info.Size() {
+		t.Errorf("Size = %d, want %d", deb.Size, info.Size())
+	}
+
+	for _, tool := range []struct {
+		name string
+		got  []byte
+	}{
+		{"md5sum", deb.MD5},
+		{"sha256sum", deb.SHA256},
+	} {
+		want := system_digest(t, tool.name, fixture_deb)
+		if want == nil {
+			continue
+		}
+		if !bytes.Equal(tool.got, want) {
+			t.Errorf("%s: got %x, want %x", tool.name, tool.got, want)
+		}
+	}
+}
+
+// system_digest shells out to the given digest tool (e.g. md5sum,
+// sha256sum) and returns the raw digest bytes, or nil if the tool isn't
+// available on this machine.
+func system_digest(t *testing.T, tool, filename string) []byte {
+	t.Helper()
+	out, err := exec.Command(tool, filename).Output()
+	if err != nil {
+		t.Skipf("%s not available: %v", tool, err)
+		return nil
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		t.Fatalf("unexpected %s output: %q", tool, out)
+	}
+	digest, err := hex.DecodeString(fields[0])
+	if err != nil {
+		t.Fatalf("decoding %s output %q: %v", tool, fields[0], err)
+	}
+	return digest
+}
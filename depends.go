@@ -0,0 +1,120 @@
+package deb
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Dependency is a single package reference inside a Depends-style field,
+// e.g. "libc6 (>= 2.34) [amd64]". Alternatives holds the "|"-separated
+// fallbacks for the group this dependency belongs to, in order.
+type Dependency struct {
+	Name         string
+	Arch         string
+	Version      string
+	Relation     string // one of <<, <=, =, >=, >>
+	Alternatives []Dependency
+}
+
+var dependency_relations = []string{"<<", "<=", "=", ">=", ">>"}
+
+// ParseDependencies parses a comma-separated Depends/Recommends/Conflicts
+// style field into one Dependency per alternative group, with the "|"
+// alternatives for that group attached to its Alternatives field.
+func ParseDependencies(value string) ([]Dependency, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	var deps []Dependency
+	for _, group := range strings.Split(value, ",") {
+		dep, err := parse_dependency_group(group)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+func parse_dependency_group(group string) (Dependency, error) {
+	alternatives := strings.Split(group, "|")
+	parsed := make([]Dependency, 0, len(alternatives))
+	for _, alternative := range alternatives {
+		dep, err := parse_dependency(alternative)
+		if err != nil {
+			return Dependency{}, err
+		}
+		parsed = append(parsed, dep)
+	}
+	head := parsed[0]
+	head.Alternatives = parsed[1:]
+	return head, nil
+}
+
+func parse_dependency(value string) (Dependency, error) {
+	value = strings.TrimSpace(value)
+	if strings.Count(value, "(") != strings.Count(value, ")") {
+		return Dependency{}, errors.Errorf("unbalanced parentheses in dependency %q", value)
+	}
+	if strings.Count(value, "[") != strings.Count(value, "]") {
+		return Dependency{}, errors.Errorf("unbalanced brackets in dependency %q", value)
+	}
+	dep := Dependency{}
+	if open := strings.Index(value, "["); open >= 0 {
+		close_ := strings.Index(value, "]")
+		if close_ < open {
+			return Dependency{}, errors.Errorf("malformed architecture qualifier in dependency %q", value)
+		}
+		dep.Arch = strings.TrimSpace(value[open+1 : close_])
+		value = strings.TrimSpace(value[:open] + value[close_+1:])
+	}
+	if open := strings.Index(value, "("); open >= 0 {
+		close_ := strings.Index(value, ")")
+		if close_ < open {
+			return Dependency{}, errors.Errorf("malformed version constraint in dependency %q", value)
+		}
+		relation, version, err := parse_version_constraint(strings.TrimSpace(value[open+1 : close_]))
+		if err != nil {
+			return Dependency{}, err
+		}
+		dep.Relation = relation
+		dep.Version = version
+		value = strings.TrimSpace(value[:open] + value[close_+1:])
+	}
+	name := strings.TrimSpace(value)
+	if name == "" {
+		return Dependency{}, errors.Errorf("missing package name in dependency %q", value)
+	}
+	dep.Name = name
+	return dep, nil
+}
+
+func parse_version_constraint(value string) (string, string, error) {
+	for _, relation := range dependency_relations {
+		if strings.HasPrefix(value, relation) {
+			return relation, strings.TrimSpace(strings.TrimPrefix(value, relation)), nil
+		}
+	}
+	return "", "", errors.Errorf("unknown relation in version constraint %q", value)
+}
+
+// group_dependencies parses a Depends-style field into the [][]Dependency
+// shape used by DEBPackage, each inner slice being one alternative group.
+func group_dependencies(value string) ([][]Dependency, error) {
+	deps, err := ParseDependencies(value)
+	if err != nil {
+		return nil, err
+	}
+	if deps == nil {
+		return nil, nil
+	}
+	groups := make([][]Dependency, 0, len(deps))
+	for _, dep := range deps {
+		alternatives := dep.Alternatives
+		dep.Alternatives = nil
+		groups = append(groups, append([]Dependency{dep}, alternatives...))
+	}
+	return groups, nil
+}
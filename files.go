@@ -0,0 +1,208 @@
+package deb
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/blakesmith/ar"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+// FileEntry describes one entry of a package's data.tar archive.
+type FileEntry struct {
+	Name     string
+	Mode     os.FileMode
+	Size     int64
+	Uid      int
+	Gid      int
+	Linkname string
+	Typeflag byte
+	ModTime  time.Time
+}
+
+// PathTraversalError is returned by Files and OpenFile when a data.tar
+// entry name escapes the archive root via ".." or an absolute path.
+type PathTraversalError struct {
+	Path string
+}
+
+func (e *PathTraversalError) Error() string {
+	return fmt.Sprintf("unsafe path %q in data.tar", e.Path)
+}
+
+// Files walks the package's data.tar member and returns metadata for every
+// entry. It requires deb to have been parsed with a Filename (see Parse and
+// ParseOptions), since the data.tar contents are re-read on demand rather
+// than kept in memory.
+func (deb *DEBPackage) Files() ([]FileEntry, error) {
+	tar_reader, closer, err := deb.open_data_tar()
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	var entries []FileEntry
+	for {
+		header, err := tar_reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				return entries, nil
+			}
+			return nil, errors.Wrap(err, "Error reading data.tar")
+		}
+		name, err := clean_tar_path(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, FileEntry{
+			Name:     name,
+			Mode:     os.FileMode(header.Mode),
+			Size:     header.Size,
+			Uid:      header.Uid,
+			Gid:      header.Gid,
+			Linkname: header.Linkname,
+			Typeflag: header.Typeflag,
+			ModTime:  header.ModTime,
+		})
+	}
+}
+
+// OpenFile streams the content of a single data.tar entry. The caller must
+// Close the returned reader. It requires deb to have been parsed with a
+// Filename, for the same reason as Files.
+func (deb *DEBPackage) OpenFile(name string) (io.ReadCloser, error) {
+	clean_name, err := clean_tar_path(name)
+	if err != nil {
+		return nil, err
+	}
+	tar_reader, closer, err := deb.open_data_tar()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		header, err := tar_reader.Next()
+		if err != nil {
+			closer.Close()
+			if err == io.EOF {
+				return nil, errors.Errorf("No such file %q in data.tar", name)
+			}
+			return nil, errors.Wrap(err, "Error reading data.tar")
+		}
+		entry_name, err := clean_tar_path(header.Name)
+		if err != nil {
+			closer.Close()
+			return nil, err
+		}
+		if entry_name == clean_name {
+			return &tar_entry_reader{reader: tar_reader, closer: closer}, nil
+		}
+	}
+}
+
+func clean_tar_path(name string) (string, error) {
+	name = strings.TrimPrefix(name, "./")
+	if name == "" {
+		return ".", nil
+	}
+	if path.IsAbs(name) {
+		return "", &PathTraversalError{Path: name}
+	}
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", &PathTraversalError{Path: name}
+	}
+	return cleaned, nil
+}
+
+// tar_entry_reader pairs a *tar.Reader positioned at one entry with the
+// closer for everything backing it (the decompressor and the open file).
+type tar_entry_reader struct {
+	reader io.Reader
+	closer io.Closer
+}
+
+func (t *tar_entry_reader) Read(p []byte) (int, error) {
+	return t.reader.Read(p)
+}
+
+func (t *tar_entry_reader) Close() error {
+	return t.closer.Close()
+}
+
+// closer_funcs runs a sequence of close actions, collecting the first error.
+type closer_funcs []func() error
+
+func (c closer_funcs) Close() error {
+	var first error
+	for _, close_fn := range c {
+		if err := close_fn(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// open_data_tar locates the data.tar.* member of deb's archive file and
+// returns a *tar.Reader over its decompressed contents, along with a closer
+// for the decompressor and the underlying file.
+func (deb *DEBPackage) open_data_tar() (*tar.Reader, io.Closer, error) {
+	if deb.Filename == "" {
+		return nil, nil, errors.New("Files/OpenFile require a package parsed with a Filename")
+	}
+	file, err := os.Open(deb.Filename)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Cannot open %s", deb.Filename)
+	}
+	ar_reader := ar.NewReader(file)
+	for {
+		header, err := ar_reader.Next()
+		if err != nil {
+			file.Close()
+			if err == io.EOF {
+				return nil, nil, errors.Errorf("No data.tar member found in %s", deb.Filename)
+			}
+			return nil, nil, errors.Wrapf(err, "Error reading %s", deb.Filename)
+		}
+		limit_reader := io.LimitReader(ar_reader, header.Size)
+		switch header.Name {
+		case "data.tar.gz":
+			gz_reader, err := gzip.NewReader(limit_reader)
+			if err != nil {
+				file.Close()
+				return nil, nil, errors.Wrap(err, "Error decompressing data.tar.gz")
+			}
+			return tar.NewReader(gz_reader), closer_funcs{gz_reader.Close, file.Close}, nil
+		case "data.tar.xz":
+			xz_reader, err := xz.NewReader(limit_reader)
+			if err != nil {
+				file.Close()
+				return nil, nil, errors.Wrap(err, "Error decompressing data.tar.xz")
+			}
+			return tar.NewReader(xz_reader), closer_funcs{file.Close}, nil
+		case "data.tar.zst":
+			zst_reader, err := zstd.NewReader(limit_reader)
+			if err != nil {
+				file.Close()
+				return nil, nil, errors.Wrap(err, "error decompressing data.tar.zst")
+			}
+			close_zst := func() error {
+				zst_reader.Close()
+				return nil
+			}
+			return tar.NewReader(zst_reader), closer_funcs{close_zst, file.Close}, nil
+		case "data.tar.bz2":
+			bz2_reader := bzip2.NewReader(limit_reader)
+			return tar.NewReader(bz2_reader), closer_funcs{file.Close}, nil
+		default:
+			continue
+		}
+	}
+}
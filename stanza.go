@@ -0,0 +1,116 @@
+package deb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxFieldSize bounds both the size of a single stanza line and the total
+// accumulated size of a field's value once continuation lines are folded
+// in, so a runaway continuation block can't grow a value without limit.
+const MaxFieldSize = 2 * 1024 * 1024
+
+// Stanza holds every field of a single RFC-822-style control paragraph,
+// keyed by the field name exactly as it appeared in the input. Lookups via
+// Get are case-insensitive, matching the Debian policy field-name rules.
+type Stanza map[string]string
+
+// Get returns the value of name, comparing field names case-insensitively.
+func (s Stanza) Get(name string) (string, bool) {
+	if value, ok := s[name]; ok {
+		return value, true
+	}
+	lower := strings.ToLower(name)
+	for key, value := range s {
+		if strings.ToLower(key) == lower {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// StanzaError reports malformed input encountered while parsing a stanza.
+type StanzaError struct {
+	Line    int
+	Message string
+}
+
+func (e *StanzaError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ParseStanza reads a single blank-line-terminated paragraph from r.
+func ParseStanza(r io.Reader) (Stanza, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), MaxFieldSize)
+	return scan_stanza(scanner)
+}
+
+// ParseStanzas reads every blank-line-separated paragraph from r, as found
+// in Packages and Sources index files.
+func ParseStanzas(r io.Reader) ([]Stanza, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), MaxFieldSize)
+	var stanzas []Stanza
+	for {
+		stanza, err := scan_stanza(scanner)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		stanzas = append(stanzas, stanza)
+	}
+	return stanzas, nil
+}
+
+func scan_stanza(scanner *bufio.Scanner) (Stanza, error) {
+	stanza := Stanza{}
+	field := ""
+	line_num := 0
+	for scanner.Scan() {
+		line_num++
+		line := scanner.Text()
+		if line == "" {
+			if len(stanza) == 0 {
+				continue
+			}
+			return stanza, nil
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if field == "" {
+				return nil, &StanzaError{Line: line_num, Message: "continuation line before first field"}
+			}
+			value := line[1:]
+			if value == "." {
+				value = ""
+			}
+			if len(stanza[field])+len("\n")+len(value) > MaxFieldSize {
+				return nil, &StanzaError{Line: line_num, Message: fmt.Sprintf("field %q exceeds MaxFieldSize of %d bytes", field, MaxFieldSize)}
+			}
+			stanza[field] += "\n" + value
+			continue
+		}
+		fields := strings.SplitN(line, ": ", 2)
+		if len(fields) != 2 {
+			return nil, &StanzaError{Line: line_num, Message: fmt.Sprintf("missing \": \" in %q", line)}
+		}
+		field = strings.TrimSpace(fields[0])
+		if field == "" {
+			return nil, &StanzaError{Line: line_num, Message: "empty field name"}
+		}
+		stanza[field] = strings.TrimSpace(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error scanning stanza")
+	}
+	if len(stanza) == 0 {
+		return nil, io.EOF
+	}
+	return stanza, nil
+}
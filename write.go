@@ -0,0 +1,172 @@
+package deb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteTo emits deb as a single Packages-file stanza, with fields in
+// Debian's canonical order. Empty fields are omitted.
+func (deb *DEBPackage) WriteTo(w io.Writer) error {
+	if err := write_field(w, "Package", deb.Name); err != nil {
+		return err
+	}
+	if err := write_field(w, "Priority", deb.Priority); err != nil {
+		return err
+	}
+	if err := write_field(w, "Section", deb.Section); err != nil {
+		return err
+	}
+	if deb.InstalledSize != 0 {
+		if err := write_field(w, "Installed-Size", strconv.FormatInt(deb.InstalledSize, 10)); err != nil {
+			return err
+		}
+	}
+	if err := write_field(w, "Maintainer", deb.Maintainer); err != nil {
+		return err
+	}
+	if err := write_field(w, "Architecture", deb.Architecture); err != nil {
+		return err
+	}
+	if source, ok := deb.Raw.Get("Source"); ok {
+		if err := write_field(w, "Source", source); err != nil {
+			return err
+		}
+	}
+	if err := write_field(w, "Version", deb.Version); err != nil {
+		return err
+	}
+	if err := write_field(w, "Depends", format_dependencies(deb.Depends)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Pre-Depends", format_dependencies(deb.PreDepends)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Recommends", format_dependencies(deb.Recommends)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Suggests", format_dependencies(deb.Suggests)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Conflicts", format_dependencies(deb.Conflicts)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Breaks", format_dependencies(deb.Breaks)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Replaces", format_dependencies(deb.Replaces)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Provides", format_dependencies(deb.Provides)); err != nil {
+		return err
+	}
+	if err := write_field(w, "Filename", deb.Filename); err != nil {
+		return err
+	}
+	if deb.Size != 0 {
+		if err := write_field(w, "Size", strconv.FormatInt(deb.Size, 10)); err != nil {
+			return err
+		}
+	}
+	if len(deb.MD5) > 0 {
+		if err := write_field(w, "MD5sum", hex.EncodeToString(deb.MD5)); err != nil {
+			return err
+		}
+	}
+	if len(deb.SHA1) > 0 {
+		if err := write_field(w, "SHA1", hex.EncodeToString(deb.SHA1)); err != nil {
+			return err
+		}
+	}
+	if len(deb.SHA256) > 0 {
+		if err := write_field(w, "SHA256", hex.EncodeToString(deb.SHA256)); err != nil {
+			return err
+		}
+	}
+	if err := write_description(w, deb.Description); err != nil {
+		return err
+	}
+	if err := write_field(w, "Homepage", deb.Homepage); err != nil {
+		return err
+	}
+	return write_field(w, "Built-Using", strings.Join(deb.BuiltUsing, ", "))
+}
+
+// WritePackagesIndex concatenates the stanzas for pkgs, separated by a
+// blank line, in the format of a Debian repository Packages file.
+func WritePackagesIndex(w io.Writer, pkgs []*DEBPackage) error {
+	for i, pkg := range pkgs {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := pkg.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func write_field(w io.Writer, name, value string) error {
+	if value == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "%s: %s\n", name, value)
+	return err
+}
+
+// write_description writes the Description field using the canonical
+// continuation-line format: a leading space on each wrapped line, and a
+// lone "." for a blank line in the long description.
+func write_description(w io.Writer, description string) error {
+	if description == "" {
+		return nil
+	}
+	lines := strings.Split(description, "\n")
+	if _, err := fmt.Fprintf(w, "Description: %s\n", lines[0]); err != nil {
+		return err
+	}
+	for _, line := range lines[1:] {
+		if line == "" {
+			line = "."
+		}
+		if _, err := fmt.Fprintf(w, " %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func format_dependencies(groups [][]Dependency) string {
+	if len(groups) == 0 {
+		return ""
+	}
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		parts[i] = format_dependency_group(group)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func format_dependency_group(group []Dependency) string {
+	parts := make([]string, len(group))
+	for i, dep := range group {
+		parts[i] = format_dependency(dep)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func format_dependency(dep Dependency) string {
+	s := dep.Name
+	if dep.Relation != "" {
+		s += fmt.Sprintf(" (%s %s)", dep.Relation, dep.Version)
+	}
+	if dep.Arch != "" {
+		s += fmt.Sprintf(" [%s]", dep.Arch)
+	}
+	return s
+}